@@ -0,0 +1,185 @@
+package vaultclient
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+)
+
+// SecretIdSourceError is returned when an AppRole config specifies zero or
+// more than one of AppRoleSecretId, AppRoleSecretIdFile, AppRoleSecretIdEnv
+// and AppRoleWrappingToken: exactly one must be set.
+type SecretIdSourceError struct {
+	Count int
+}
+
+func (e *SecretIdSourceError) Error() string {
+	return fmt.Sprintf("approle: expected exactly one of AppRoleSecretId, AppRoleSecretIdFile, AppRoleSecretIdEnv or AppRoleWrappingToken to be set, got %d", e.Count)
+}
+
+func newAppRoleAuth(c *api.Client, cfg *Config) (*appRoleAuth, error) {
+	sources := 0
+	for _, s := range []string{cfg.AppRoleSecretId, cfg.AppRoleSecretIdFile, cfg.AppRoleSecretIdEnv, cfg.AppRoleWrappingToken} {
+		if s != "" {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return nil, &SecretIdSourceError{Count: sources}
+	}
+
+	return &appRoleAuth{
+		client:        c,
+		role:          cfg.AppRole,
+		roleId:        cfg.AppRoleId,
+		secretId:      cfg.AppRoleSecretId,
+		secretIdFile:  cfg.AppRoleSecretIdFile,
+		secretIdEnv:   cfg.AppRoleSecretIdEnv,
+		wrappingToken: cfg.AppRoleWrappingToken,
+	}, nil
+}
+
+// resolveSecretId returns the secret_id to log in with, reading it fresh
+// from whichever source is configured so that a rotated file or a changed
+// environment variable is picked up on every login. A wrapping token is the
+// one given at construction, and it is unwrapped again on every call.
+func (a *appRoleAuth) resolveSecretId() (string, error) {
+	switch {
+	case a.secretId != "":
+		return a.secretId, nil
+	case a.secretIdFile != "":
+		b, err := os.ReadFile(a.secretIdFile)
+		if err != nil {
+			return "", fmt.Errorf("read approle secret_id from %q: %w", a.secretIdFile, err)
+		}
+		return strings.TrimSpace(string(b)), nil
+	case a.secretIdEnv != "":
+		return os.Getenv(a.secretIdEnv), nil
+	case a.wrappingToken != "":
+		return a.unwrapSecretId()
+	default:
+		return "", &SecretIdSourceError{Count: 0}
+	}
+}
+
+func (a *appRoleAuth) unwrapSecretId() (string, error) {
+	secret, err := a.client.Logical().Unwrap(a.wrappingToken)
+	if err != nil {
+		return "", fmt.Errorf("unwrap approle secret_id: %w", err)
+	}
+	secretId, ok := secret.Data["secret_id"].(string)
+	if !ok {
+		return "", fmt.Errorf("unwrap approle secret_id: response missing secret_id")
+	}
+	return secretId, nil
+}
+
+// loginPath returns the approle login path for the configured mount,
+// defaulting to the method's default mount when a.role (Config.AppRole) is
+// unset.
+func (a *appRoleAuth) loginPath() string {
+	mount := a.role
+	if mount == "" {
+		mount = "approle"
+	}
+	return fmt.Sprintf("auth/%s/login", mount)
+}
+
+func (a *appRoleAuth) getAuth() (*Auth, error) {
+	secretId, err := a.resolveSecretId()
+	if err != nil {
+		return nil, err
+	}
+
+	data := map[string]interface{}{
+		"role_id":   a.roleId,
+		"secret_id": secretId,
+	}
+
+	resp, err := a.client.Logical().Write(a.loginPath(), data)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenTtl, err := resp.TokenTTL()
+	if err != nil {
+		return nil, err
+	}
+
+	issued := time.Now().UTC()
+	return &Auth{
+		token:  resp.Auth.ClientToken,
+		issued: issued,
+		ttl:    tokenTtl,
+		expiry: issued.Add(tokenTtl),
+	}, nil
+}
+
+func (a *appRoleAuth) VaultClient() (*api.Client, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !a.auth.IsTokenExpired() {
+		return a.client, nil
+	}
+
+	auth, err := a.getAuth()
+	if err != nil {
+		return nil, err
+	}
+	a.auth = auth
+	a.client.SetToken(auth.token)
+	return a.client, nil
+}
+
+func (a *appRoleAuth) VaultClientOrPanic() *api.Client {
+	client, err := a.VaultClient()
+	if err != nil {
+		panic(err)
+	}
+	return client
+}
+
+// Run proactively renews the AppRole login in the background instead of
+// waiting for VaultClient to see an expired token. It blocks until ctx is
+// done or Stop is called.
+func (a *appRoleAuth) Run(ctx context.Context) error {
+	a.mu.Lock()
+	if a.auth == nil || a.auth.IsTokenExpired() {
+		auth, err := a.getAuth()
+		if err != nil {
+			a.mu.Unlock()
+			return err
+		}
+		a.auth = auth
+		a.client.SetToken(auth.token)
+	}
+	initial := a.auth
+	a.mu.Unlock()
+
+	return a.runner.run(ctx, initial, func() (*Auth, error) {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		auth, err := a.getAuth()
+		if err != nil {
+			return nil, err
+		}
+		a.auth = auth
+		a.client.SetToken(auth.token)
+		return auth, nil
+	}, func() error {
+		return revokeClientToken(a.client)
+	})
+}
+
+func (a *appRoleAuth) Notify() <-chan error {
+	return a.runner.Notify()
+}
+
+func (a *appRoleAuth) Stop() {
+	a.runner.Stop()
+}
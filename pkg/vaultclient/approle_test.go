@@ -0,0 +1,103 @@
+package vaultclient
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewAppRoleAuthSecretIdSources(t *testing.T) {
+	base := func() *Config {
+		cfg := BaseConfig()
+		cfg.AppRoleId = "role-id"
+		return cfg
+	}
+
+	tests := []struct {
+		name    string
+		setup   func(cfg *Config)
+		wantErr bool
+	}{
+		{"secret_id", func(c *Config) { c.AppRoleSecretId = "s" }, false},
+		{"secret_id_file", func(c *Config) { c.AppRoleSecretIdFile = "/tmp/secret" }, false},
+		{"secret_id_env", func(c *Config) { c.AppRoleSecretIdEnv = "ENV" }, false},
+		{"wrapping_token", func(c *Config) { c.AppRoleWrappingToken = "t" }, false},
+		{"none set", func(c *Config) {}, true},
+		{"two set", func(c *Config) { c.AppRoleSecretId, c.AppRoleSecretIdEnv = "s", "ENV" }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := base()
+			tt.setup(cfg)
+
+			_, err := newAppRoleAuth(nil, cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newAppRoleAuth() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if _, ok := err.(*SecretIdSourceError); !ok {
+					t.Fatalf("error = %T, want *SecretIdSourceError", err)
+				}
+			}
+		})
+	}
+}
+
+func TestResolveSecretId(t *testing.T) {
+	secretFile := filepath.Join(t.TempDir(), "secret-id")
+	if err := os.WriteFile(secretFile, []byte("file-secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		setup   func(a *appRoleAuth)
+		envs    map[string]string
+		want    string
+		wantErr bool
+	}{
+		{"literal", func(a *appRoleAuth) { a.secretId = "literal-secret" }, nil, "literal-secret", false},
+		{"file", func(a *appRoleAuth) { a.secretIdFile = secretFile }, nil, "file-secret", false},
+		{"env", func(a *appRoleAuth) { a.secretIdEnv = "APPROLE_SECRET_ID_TEST" }, map[string]string{"APPROLE_SECRET_ID_TEST": "env-secret"}, "env-secret", false},
+		{"none configured", func(a *appRoleAuth) {}, nil, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.envs {
+				t.Setenv(k, v)
+			}
+
+			var a appRoleAuth
+			tt.setup(&a)
+			got, err := a.resolveSecretId()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveSecretId() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Fatalf("resolveSecretId() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAppRoleLoginPath(t *testing.T) {
+	tests := []struct {
+		name string
+		role string
+		want string
+	}{
+		{"configured mount", "custom-approle", "auth/custom-approle/login"},
+		{"default mount", "", "auth/approle/login"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := appRoleAuth{role: tt.role}
+			if got := a.loginPath(); got != tt.want {
+				t.Fatalf("loginPath() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
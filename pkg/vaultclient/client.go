@@ -1,8 +1,10 @@
 package vaultclient
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/hashicorp/vault/api"
@@ -19,21 +21,38 @@ const (
 	EnvVarStsAwsRegion = "STS_AWS_REGION"
 )
 
+// defaultK8sTokenPath is where kubelet projects the pod's service-account
+// JWT by default.
+const defaultK8sTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+// defaultK8sPath is the Kubernetes auth method's default mount.
+const defaultK8sPath = "kubernetes"
+
 type k8sAuth struct {
-	client *api.Client
-	role   string
-	path   string
-	auth   *Auth
+	client    *api.Client
+	role      string
+	path      string
+	tokenPath string
+	auth      *Auth
+	mu        sync.Mutex
+	runner    renewalRunner
 }
 
 type iamAuth struct {
-	role   string
-	client *api.Client
-	auth   *Auth
+	role           string
+	client         *api.Client
+	iamHeaderValue string
+	iamServerId    string
+	auth           *Auth
+	mu             sync.Mutex
+	runner         renewalRunner
 }
 
 type tokenAuth struct {
-	client *api.Client
+	client   *api.Client
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	mu       sync.Mutex
 }
 
 type appRoleAuth struct {
@@ -42,6 +61,14 @@ type appRoleAuth struct {
 	role     string
 	roleId   string
 	secretId string
+	// secretIdFile, secretIdEnv and wrappingToken are the alternative
+	// secret_id sources; exactly one of secretId/secretIdFile/secretIdEnv/
+	// wrappingToken is set, enforced by newAppRoleAuth.
+	secretIdFile  string
+	secretIdEnv   string
+	wrappingToken string
+	runner        renewalRunner
+	mu            sync.Mutex
 }
 
 type Config struct {
@@ -52,12 +79,35 @@ type Config struct {
 	AppRole         string
 	AppRoleId       string
 	AppRoleSecretId string
-	K8sRole         string
-	K8sPath         string
+	// AppRoleSecretIdFile, AppRoleSecretIdEnv and AppRoleWrappingToken are
+	// alternatives to AppRoleSecretId: exactly one of the four must be set.
+	// AppRoleSecretIdFile is read fresh on every login, so a sidecar can
+	// rotate the secret_id on disk. AppRoleSecretIdEnv names an environment
+	// variable to read the secret_id from indirectly. AppRoleWrappingToken
+	// is a single-use response-wrapping token that getAuth unwraps via
+	// sys/wrapping/unwrap to obtain the secret_id.
+	AppRoleSecretIdFile  string
+	AppRoleSecretIdEnv   string
+	AppRoleWrappingToken string
+	K8sRole              string
+	K8sPath              string
+	// K8sTokenPath is where the service-account JWT is read from for
+	// Kubernetes auth. Defaults to defaultK8sTokenPath.
+	K8sTokenPath string
+	// IamHeaderValue, if set, is signed into the STS request as the
+	// X-Vault-AWS-IAM-Server-ID header, per the aws auth method's
+	// iam_server_id_header_value check.
+	IamHeaderValue string
+	// IamServerId overrides the STS endpoint host (e.g.
+	// "sts.us-east-1.amazonaws.com"); left empty, it's derived from
+	// AWS_REGION/STS_AWS_REGION.
+	IamServerId string
 }
 
 type Auth struct {
 	token  string
+	issued time.Time
+	ttl    time.Duration
 	expiry time.Time
 }
 
@@ -68,6 +118,18 @@ var (
 type VaultAuth interface {
 	VaultClient() (*api.Client, error)
 	VaultClientOrPanic() *api.Client
+
+	// Run proactively renews this auth's token in the background until ctx
+	// is done or Stop is called, instead of relying on VaultClient to catch
+	// an expired token on demand.
+	Run(ctx context.Context) error
+	// Notify returns a channel that receives an error when a background
+	// renewal fails. For the AppRole, Kubernetes and IAM auths it buffers
+	// one error and drops further ones while that one is unread.
+	Notify() <-chan error
+	// Stop ends the Run loop. The AppRole, Kubernetes and IAM auths revoke
+	// their token as Run returns; a static Token auth's token is not revoked.
+	Stop()
 }
 
 func BaseConfig() *Config {
@@ -99,6 +161,8 @@ func NewDefaultConfig() *Config {
 	if role != "" {
 		config.AuthType = Iam
 		config.IamRole = role
+		config.IamHeaderValue = os.Getenv("VAULT_IAM_HEADER_VALUE")
+		config.IamServerId = os.Getenv("VAULT_IAM_SERVER_ID")
 
 		return config
 	}
@@ -112,6 +176,11 @@ func NewDefaultConfig() *Config {
 			k8sPath = fmt.Sprintf("k8s-%s", k8sRole)
 		}
 		config.K8sPath = k8sPath
+		k8sTokenPath := os.Getenv("K8S_TOKEN_PATH")
+		if k8sTokenPath == "" {
+			k8sTokenPath = defaultK8sTokenPath
+		}
+		config.K8sTokenPath = k8sTokenPath
 
 		return config
 	}
@@ -141,22 +210,28 @@ func NewVaultAuth(cfg *Config) (VaultAuth, error) {
 			client: c,
 		}, nil
 	case AppRole:
-		return &appRoleAuth{
-			client:   c,
-			role:     cfg.AppRole,
-			secretId: cfg.AppRoleSecretId,
-			roleId:   cfg.AppRoleId,
-		}, nil
+		return newAppRoleAuth(c, cfg)
 	case Iam:
 		return &iamAuth{
-			client: c,
-			role:   cfg.IamRole,
+			client:         c,
+			role:           cfg.IamRole,
+			iamHeaderValue: cfg.IamHeaderValue,
+			iamServerId:    cfg.IamServerId,
 		}, nil
 	case K8s:
+		k8sTokenPath := cfg.K8sTokenPath
+		if k8sTokenPath == "" {
+			k8sTokenPath = defaultK8sTokenPath
+		}
+		k8sPath := cfg.K8sPath
+		if k8sPath == "" {
+			k8sPath = defaultK8sPath
+		}
 		return &k8sAuth{
-			client: c,
-			role:   cfg.K8sRole,
-			path:   cfg.K8sPath,
+			client:    c,
+			role:      cfg.K8sRole,
+			path:      k8sPath,
+			tokenPath: k8sTokenPath,
 		}, nil
 
 	}
@@ -183,46 +258,36 @@ func (t *tokenAuth) VaultClientOrPanic() *api.Client {
 	return client
 }
 
-func (a *appRoleAuth) getAuth() (*Auth, error) {
-	data := map[string]interface{}{
-		"role_id":   a.roleId,
-		"secret_id": a.secretId,
-	}
-
-	resp, err := a.client.Logical().Write("auth/approle/login", data)
-	if err != nil {
-		return nil, err
-	}
-
-	tokenTtl, err := resp.TokenTTL()
-	if err != nil {
-		return nil, err
+func (t *tokenAuth) lazyInit() chan struct{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.stopCh == nil {
+		t.stopCh = make(chan struct{})
 	}
-
-	return &Auth{
-		token:  resp.Auth.ClientToken,
-		expiry: time.Now().UTC().Add(tokenTtl),
-	}, nil
+	return t.stopCh
 }
 
-func (a *appRoleAuth) VaultClient() (*api.Client, error) {
-	if !a.auth.IsTokenExpired() {
-		return a.client, nil
+// Run is a no-op for a static token: there is nothing to renew, so it just
+// blocks until ctx is done or Stop is called.
+func (t *tokenAuth) Run(ctx context.Context) error {
+	stopCh := t.lazyInit()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-stopCh:
+		return nil
 	}
+}
 
-	var err error
-	a.auth, err = a.getAuth()
-	if err != nil {
-		return nil, err
-	}
-	a.client.SetToken(a.auth.token)
-	return a.client, nil
+func (t *tokenAuth) Notify() <-chan error {
+	return make(chan error)
 }
 
-func (a *appRoleAuth) VaultClientOrPanic() *api.Client {
-	client, err := a.VaultClient()
-	if err != nil {
-		panic(err)
-	}
-	return client
+// Stop ends a running Run loop. Safe to call more than once, or before Run
+// has started.
+func (t *tokenAuth) Stop() {
+	stopCh := t.lazyInit()
+	t.stopOnce.Do(func() {
+		close(stopCh)
+	})
 }
@@ -0,0 +1,199 @@
+package vaultclient
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// fileConfig is the on-disk shape accepted by LoadConfig/LoadConfigFromReader.
+// YAML and JSON documents both decode through the YAML parser, since JSON is
+// a subset of YAML for the simple, untagged documents this takes.
+type fileConfig struct {
+	Auth struct {
+		Type           string `yaml:"type"`
+		Mount          string `yaml:"mount"`
+		RoleId         string `yaml:"role_id"`
+		SecretId       string `yaml:"secret_id"`
+		SecretIdFile   string `yaml:"secret_id_file"`
+		SecretIdEnv    string `yaml:"secret_id_env"`
+		WrappingToken  string `yaml:"wrapping_token"`
+		Role           string `yaml:"role"`
+		JwtPath        string `yaml:"jwt_path"`
+		IamHeaderValue string `yaml:"iam_header_value"`
+		IamServerId    string `yaml:"iam_server_id"`
+		Token          string `yaml:"token"`
+	} `yaml:"auth"`
+}
+
+// LoadConfig reads and parses the YAML or JSON config file at path. See
+// LoadConfigFromReader for the document shape and field precedence.
+func LoadConfig(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("vaultclient: open config %q: %w", path, err)
+	}
+	defer f.Close()
+
+	return LoadConfigFromReader(f)
+}
+
+// LoadConfigFromReader parses a YAML or JSON document describing the auth
+// stanza, e.g.
+//
+//	auth:
+//	  type: approle
+//	  mount: approle
+//	  role_id: ...
+//	  secret_id_file: ...
+//
+// or
+//
+//	auth:
+//	  type: kubernetes
+//	  role: ...
+//	  jwt_path: ...
+//
+// Fields are applied with precedence file -> env -> explicit: the file
+// populates the Config, then each non-empty environment variable that
+// NewDefaultConfig reads for the file's auth type replaces the matching
+// field, and any fields the caller sets on the returned Config afterwards
+// take final effect. A document with no auth type takes the whole Config
+// from NewDefaultConfig's env auto-detect.
+func LoadConfigFromReader(r io.Reader) (*Config, error) {
+	var fc fileConfig
+	if err := yaml.NewDecoder(r).Decode(&fc); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("vaultclient: decode config: %w", err)
+	}
+
+	config := BaseConfig()
+
+	switch strings.ToLower(fc.Auth.Type) {
+	case "approle":
+		config.AuthType = AppRole
+		config.AppRole = fc.Auth.Mount
+		config.AppRoleId = fc.Auth.RoleId
+		config.AppRoleSecretId = fc.Auth.SecretId
+		config.AppRoleSecretIdFile = fc.Auth.SecretIdFile
+		config.AppRoleSecretIdEnv = fc.Auth.SecretIdEnv
+		config.AppRoleWrappingToken = fc.Auth.WrappingToken
+	case "kubernetes", "k8s":
+		config.AuthType = K8s
+		config.K8sRole = fc.Auth.Role
+		config.K8sPath = fc.Auth.Mount
+		config.K8sTokenPath = fc.Auth.JwtPath
+	case "iam", "aws":
+		config.AuthType = Iam
+		config.IamRole = fc.Auth.Role
+		config.IamHeaderValue = fc.Auth.IamHeaderValue
+		config.IamServerId = fc.Auth.IamServerId
+	case "token":
+		config.AuthType = Token
+		config.Token = fc.Auth.Token
+	case "":
+		// No auth stanza in the file; fall through to a pure env auto-detect.
+	default:
+		return nil, fmt.Errorf("vaultclient: unknown auth type %q", fc.Auth.Type)
+	}
+
+	applyEnvOverrides(config)
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// applyEnvOverrides lets environment variables take precedence over
+// whatever LoadConfigFromReader parsed from the file, mirroring the env
+// vars NewDefaultConfig auto-detects from. If no auth type was set at all,
+// it defers entirely to NewDefaultConfig's priority-ordered auto-detect.
+func applyEnvOverrides(config *Config) {
+	if config.AuthType == 0 {
+		apiConfig := config.Config
+		*config = *NewDefaultConfig()
+		config.Config = apiConfig
+		return
+	}
+
+	switch config.AuthType {
+	case AppRole:
+		if v := os.Getenv("VAULT_APP_ROLE"); v != "" {
+			config.AppRole = v
+		}
+		if v := os.Getenv("VAULT_APP_ROLE_ID"); v != "" {
+			config.AppRoleId = v
+		}
+		if v := os.Getenv("VAULT_APP_SECRET_ID"); v != "" {
+			config.AppRoleSecretId = v
+		}
+	case Iam:
+		if v := os.Getenv("VAULT_ROLE"); v != "" {
+			config.IamRole = v
+		}
+		if v := os.Getenv("VAULT_IAM_HEADER_VALUE"); v != "" {
+			config.IamHeaderValue = v
+		}
+		if v := os.Getenv("VAULT_IAM_SERVER_ID"); v != "" {
+			config.IamServerId = v
+		}
+	case K8s:
+		if v := os.Getenv("K8S_ROLE"); v != "" {
+			config.K8sRole = v
+		}
+		if v := os.Getenv("K8S_PATH"); v != "" {
+			config.K8sPath = v
+		}
+		if v := os.Getenv("K8S_TOKEN_PATH"); v != "" {
+			config.K8sTokenPath = v
+		}
+	case Token:
+		if v := os.Getenv("VAULT_TOKEN"); v != "" {
+			config.Token = v
+		}
+	}
+}
+
+// Validate rejects Config combinations that NewVaultAuth could never turn
+// into a working auth backend, such as AppRole with no role_id or
+// Kubernetes with an empty role.
+func (c *Config) Validate() error {
+	if c.Error != nil {
+		return c.Error
+	}
+
+	switch c.AuthType {
+	case AppRole:
+		if c.AppRoleId == "" {
+			return fmt.Errorf("vaultclient: approle auth requires a role_id")
+		}
+		sources := 0
+		for _, s := range []string{c.AppRoleSecretId, c.AppRoleSecretIdFile, c.AppRoleSecretIdEnv, c.AppRoleWrappingToken} {
+			if s != "" {
+				sources++
+			}
+		}
+		if sources != 1 {
+			return &SecretIdSourceError{Count: sources}
+		}
+	case Iam:
+		if c.IamRole == "" {
+			return fmt.Errorf("vaultclient: iam auth requires a role")
+		}
+	case K8s:
+		if c.K8sRole == "" {
+			return fmt.Errorf("vaultclient: kubernetes auth requires a role")
+		}
+	case Token:
+		if c.Token == "" {
+			return fmt.Errorf("vaultclient: token auth requires a token")
+		}
+	default:
+		return fmt.Errorf("vaultclient: unknown or unset auth type '%d'", c.AuthType)
+	}
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package vaultclient
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(cfg *Config)
+		wantErr bool
+	}{
+		{"approle valid", func(c *Config) { c.AuthType, c.AppRoleId, c.AppRoleSecretId = AppRole, "id", "secret" }, false},
+		{"approle missing role_id", func(c *Config) { c.AuthType, c.AppRoleSecretId = AppRole, "secret" }, true},
+		{"approle missing secret_id source", func(c *Config) { c.AuthType, c.AppRoleId = AppRole, "id" }, true},
+		{"approle two secret_id sources", func(c *Config) {
+			c.AuthType, c.AppRoleId, c.AppRoleSecretId, c.AppRoleSecretIdEnv = AppRole, "id", "secret", "ENV"
+		}, true},
+		{"iam valid", func(c *Config) { c.AuthType, c.IamRole = Iam, "role" }, false},
+		{"iam missing role", func(c *Config) { c.AuthType = Iam }, true},
+		{"k8s valid", func(c *Config) { c.AuthType, c.K8sRole = K8s, "role" }, false},
+		{"k8s missing role", func(c *Config) { c.AuthType = K8s }, true},
+		{"token valid", func(c *Config) { c.AuthType, c.Token = Token, "t" }, false},
+		{"token missing token", func(c *Config) { c.AuthType = Token }, true},
+		{"unset auth type", func(c *Config) {}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := BaseConfig()
+			tt.setup(cfg)
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadConfigFromReaderPrecedence(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		envs    map[string]string
+		check   func(t *testing.T, cfg *Config)
+		wantErr bool
+	}{
+		{
+			name: "approle doc example",
+			yaml: `
+auth:
+  type: approle
+  mount: approle
+  role_id: my-role-id
+  secret_id_file: /var/run/secrets/secret-id
+`,
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.AuthType != AppRole {
+					t.Fatalf("AuthType = %v, want AppRole", cfg.AuthType)
+				}
+				if cfg.AppRole != "approle" {
+					t.Fatalf("AppRole = %q, want %q", cfg.AppRole, "approle")
+				}
+				if cfg.AppRoleId != "my-role-id" {
+					t.Fatalf("AppRoleId = %q, want %q", cfg.AppRoleId, "my-role-id")
+				}
+			},
+		},
+		{
+			name: "kubernetes doc example with no mount",
+			yaml: `
+auth:
+  type: kubernetes
+  role: my-role
+  jwt_path: /var/run/secrets/kubernetes.io/serviceaccount/token
+`,
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.AuthType != K8s {
+					t.Fatalf("AuthType = %v, want K8s", cfg.AuthType)
+				}
+				if cfg.K8sRole != "my-role" {
+					t.Fatalf("K8sRole = %q, want %q", cfg.K8sRole, "my-role")
+				}
+				if cfg.K8sPath != "" {
+					t.Fatalf("K8sPath = %q, want empty (defaulted later by NewVaultAuth)", cfg.K8sPath)
+				}
+			},
+		},
+		{
+			name: "env overrides file",
+			yaml: `
+auth:
+  type: approle
+  mount: approle
+  role_id: file-role-id
+  secret_id: file-secret-id
+`,
+			envs: map[string]string{
+				"VAULT_APP_ROLE_ID": "env-role-id",
+			},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.AppRoleId != "env-role-id" {
+					t.Fatalf("AppRoleId = %q, want %q", cfg.AppRoleId, "env-role-id")
+				}
+				if cfg.AppRoleSecretId != "file-secret-id" {
+					t.Fatalf("AppRoleSecretId = %q, want %q", cfg.AppRoleSecretId, "file-secret-id")
+				}
+			},
+		},
+		{
+			name:    "unknown auth type",
+			yaml:    "auth:\n  type: bogus\n",
+			wantErr: true,
+		},
+		{
+			name:    "missing required field fails validation",
+			yaml:    "auth:\n  type: iam\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.envs {
+				t.Setenv(k, v)
+			}
+
+			cfg, err := LoadConfigFromReader(strings.NewReader(tt.yaml))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("LoadConfigFromReader() error = nil, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadConfigFromReader() error = %v, want nil", err)
+			}
+			tt.check(t, cfg)
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package vaultclient
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/hashicorp/vault/api"
+)
+
+// iamRegion resolves the STS region to sign against, preferring
+// STS_AWS_REGION over AWS_REGION.
+func iamRegion() string {
+	if region := os.Getenv(EnvVarStsAwsRegion); region != "" {
+		return region
+	}
+	return os.Getenv(EnvVarAwsRegion)
+}
+
+// stsEndpoint resolves the region to sign the STS request for, and the STS
+// endpoint host to send it to. The region always comes from
+// AWS_REGION/STS_AWS_REGION: it's part of the SigV4 credential scope, not an
+// endpoint detail. iamServerId, when set, only overrides the endpoint host
+// (e.g. a VPC endpoint or regional endpoint like "sts.us-east-1.amazonaws.com");
+// left empty, the endpoint is derived from the region.
+func stsEndpoint(iamServerId string) (region, endpoint string) {
+	region = iamRegion()
+	endpoint = iamServerId
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("sts.%s.amazonaws.com", region)
+	}
+	return region, endpoint
+}
+
+// getAuth builds a signed sts:GetCallerIdentity request and exchanges it
+// for a Vault token via the aws auth method, per
+// https://developer.hashicorp.com/vault/docs/auth/aws#iam-auth-method.
+func (a *iamAuth) getAuth() (*Auth, error) {
+	region, endpoint := stsEndpoint(a.iamServerId)
+
+	sess, err := session.NewSession(&aws.Config{
+		Region:   aws.String(region),
+		Endpoint: aws.String(endpoint),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create sts session: %w", err)
+	}
+
+	svc := sts.New(sess)
+	req, _ := svc.GetCallerIdentityRequest(&sts.GetCallerIdentityInput{})
+	if a.iamHeaderValue != "" {
+		req.HTTPRequest.Header.Add("X-Vault-AWS-IAM-Server-ID", a.iamHeaderValue)
+	}
+	if err := req.Sign(); err != nil {
+		return nil, fmt.Errorf("sign sts GetCallerIdentity request: %w", err)
+	}
+
+	headers, err := json.Marshal(req.HTTPRequest.Header)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(req.HTTPRequest.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	data := map[string]interface{}{
+		"role":                    a.role,
+		"iam_http_request_method": req.HTTPRequest.Method,
+		"iam_request_url":         base64.StdEncoding.EncodeToString([]byte(req.HTTPRequest.URL.String())),
+		"iam_request_headers":     base64.StdEncoding.EncodeToString(headers),
+		"iam_request_body":        base64.StdEncoding.EncodeToString(body),
+	}
+
+	resp, err := a.client.Logical().Write("auth/aws/login", data)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenTtl, err := resp.TokenTTL()
+	if err != nil {
+		return nil, err
+	}
+
+	issued := time.Now().UTC()
+	return &Auth{
+		token:  resp.Auth.ClientToken,
+		issued: issued,
+		ttl:    tokenTtl,
+		expiry: issued.Add(tokenTtl),
+	}, nil
+}
+
+func (a *iamAuth) VaultClient() (*api.Client, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !a.auth.IsTokenExpired() {
+		return a.client, nil
+	}
+
+	auth, err := a.getAuth()
+	if err != nil {
+		return nil, err
+	}
+	a.auth = auth
+	a.client.SetToken(auth.token)
+	return a.client, nil
+}
+
+func (a *iamAuth) VaultClientOrPanic() *api.Client {
+	client, err := a.VaultClient()
+	if err != nil {
+		panic(err)
+	}
+	return client
+}
+
+// Run proactively renews the IAM login in the background instead of
+// waiting for VaultClient to see an expired token.
+func (a *iamAuth) Run(ctx context.Context) error {
+	a.mu.Lock()
+	if a.auth == nil || a.auth.IsTokenExpired() {
+		auth, err := a.getAuth()
+		if err != nil {
+			a.mu.Unlock()
+			return err
+		}
+		a.auth = auth
+		a.client.SetToken(auth.token)
+	}
+	initial := a.auth
+	a.mu.Unlock()
+
+	return a.runner.run(ctx, initial, func() (*Auth, error) {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		auth, err := a.getAuth()
+		if err != nil {
+			return nil, err
+		}
+		a.auth = auth
+		a.client.SetToken(auth.token)
+		return auth, nil
+	}, func() error {
+		return revokeClientToken(a.client)
+	})
+}
+
+func (a *iamAuth) Notify() <-chan error {
+	return a.runner.Notify()
+}
+
+func (a *iamAuth) Stop() {
+	a.runner.Stop()
+}
@@ -0,0 +1,34 @@
+package vaultclient
+
+import (
+	"os"
+	"testing"
+)
+
+func TestStsEndpoint(t *testing.T) {
+	t.Run("region-derived default", func(t *testing.T) {
+		os.Setenv(EnvVarAwsRegion, "us-west-2")
+		defer os.Unsetenv(EnvVarAwsRegion)
+
+		region, endpoint := stsEndpoint("")
+		if region != "us-west-2" {
+			t.Fatalf("region = %q, want %q", region, "us-west-2")
+		}
+		if endpoint != "sts.us-west-2.amazonaws.com" {
+			t.Fatalf("endpoint = %q, want %q", endpoint, "sts.us-west-2.amazonaws.com")
+		}
+	})
+
+	t.Run("iamServerId overrides endpoint host only", func(t *testing.T) {
+		os.Setenv(EnvVarAwsRegion, "us-east-1")
+		defer os.Unsetenv(EnvVarAwsRegion)
+
+		region, endpoint := stsEndpoint("sts.us-gov-west-1.amazonaws.com")
+		if region != "us-east-1" {
+			t.Fatalf("region = %q, want %q", region, "us-east-1")
+		}
+		if endpoint != "sts.us-gov-west-1.amazonaws.com" {
+			t.Fatalf("endpoint = %q, want %q", endpoint, "sts.us-gov-west-1.amazonaws.com")
+		}
+	})
+}
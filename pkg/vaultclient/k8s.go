@@ -0,0 +1,104 @@
+package vaultclient
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+)
+
+func (a *k8sAuth) getAuth() (*Auth, error) {
+	jwt, err := os.ReadFile(a.tokenPath)
+	if err != nil {
+		return nil, fmt.Errorf("read k8s service account token from %q: %w", a.tokenPath, err)
+	}
+
+	data := map[string]interface{}{
+		"jwt":  string(jwt),
+		"role": a.role,
+	}
+
+	resp, err := a.client.Logical().Write(fmt.Sprintf("auth/%s/login", a.path), data)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenTtl, err := resp.TokenTTL()
+	if err != nil {
+		return nil, err
+	}
+
+	issued := time.Now().UTC()
+	return &Auth{
+		token:  resp.Auth.ClientToken,
+		issued: issued,
+		ttl:    tokenTtl,
+		expiry: issued.Add(tokenTtl),
+	}, nil
+}
+
+func (a *k8sAuth) VaultClient() (*api.Client, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !a.auth.IsTokenExpired() {
+		return a.client, nil
+	}
+
+	auth, err := a.getAuth()
+	if err != nil {
+		return nil, err
+	}
+	a.auth = auth
+	a.client.SetToken(auth.token)
+	return a.client, nil
+}
+
+func (a *k8sAuth) VaultClientOrPanic() *api.Client {
+	client, err := a.VaultClient()
+	if err != nil {
+		panic(err)
+	}
+	return client
+}
+
+// Run proactively renews the Kubernetes login in the background instead of
+// waiting for VaultClient to see an expired token.
+func (a *k8sAuth) Run(ctx context.Context) error {
+	a.mu.Lock()
+	if a.auth == nil || a.auth.IsTokenExpired() {
+		auth, err := a.getAuth()
+		if err != nil {
+			a.mu.Unlock()
+			return err
+		}
+		a.auth = auth
+		a.client.SetToken(auth.token)
+	}
+	initial := a.auth
+	a.mu.Unlock()
+
+	return a.runner.run(ctx, initial, func() (*Auth, error) {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		auth, err := a.getAuth()
+		if err != nil {
+			return nil, err
+		}
+		a.auth = auth
+		a.client.SetToken(auth.token)
+		return auth, nil
+	}, func() error {
+		return revokeClientToken(a.client)
+	})
+}
+
+func (a *k8sAuth) Notify() <-chan error {
+	return a.runner.Notify()
+}
+
+func (a *k8sAuth) Stop() {
+	a.runner.Stop()
+}
@@ -0,0 +1,112 @@
+package vaultclient
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+)
+
+// renewalJitter bounds the random offset added to a token's renewAfter so
+// that multiple auths started at the same instant don't all wake in
+// lockstep.
+const renewalJitter = 10 * time.Second
+
+// renewAfterTime computes when a should be proactively renewed: 80% of its
+// ttl past issuance, plus a small jitter window.
+func renewAfterTime(a *Auth) time.Time {
+	renewIn := time.Duration(float64(a.ttl) * 0.8)
+	jitter := time.Duration(rand.Int63n(int64(renewalJitter)))
+	return a.issued.Add(renewIn + jitter)
+}
+
+// revokeClientToken revokes client's current token, used to release a
+// backend's token on Run shutdown.
+func revokeClientToken(client *api.Client) error {
+	return client.Auth().Token().RevokeSelf("")
+}
+
+// renewalRunner is the Run/Notify/Stop implementation shared by every
+// token-based auth backend (AppRole, Kubernetes, IAM): a single timer woken
+// at the current token's renewAfter instant, calling back into the backend
+// to perform the actual re-login. Each backend owns one as a value field
+// and only ever has its own token in flight, so this is a plain loop rather
+// than a heap of multiple handles.
+type renewalRunner struct {
+	mu       sync.Mutex
+	stopCh   chan struct{}
+	notifyCh chan error
+	stopOnce sync.Once
+}
+
+func (r *renewalRunner) lazyInit() (chan struct{}, chan error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stopCh == nil {
+		r.stopCh = make(chan struct{})
+	}
+	if r.notifyCh == nil {
+		r.notifyCh = make(chan error, 1)
+	}
+	return r.stopCh, r.notifyCh
+}
+
+// Notify returns a channel that receives an error when a background renewal,
+// or the revoke on shutdown, fails. The channel buffers one error; further
+// errors are dropped while that one is unread.
+func (r *renewalRunner) Notify() <-chan error {
+	_, notifyCh := r.lazyInit()
+	return notifyCh
+}
+
+// Stop ends a running loop. Safe to call more than once, or before run has
+// started.
+func (r *renewalRunner) Stop() {
+	stopCh, _ := r.lazyInit()
+	r.stopOnce.Do(func() {
+		close(stopCh)
+	})
+}
+
+func (r *renewalRunner) notify(notifyCh chan error, err error) {
+	select {
+	case notifyCh <- err:
+	default:
+	}
+}
+
+// run blocks, renewing current via renew once it reaches its renewAfter
+// instant, until ctx is done or Stop is called. On exit it revokes the held
+// token via revoke.
+func (r *renewalRunner) run(ctx context.Context, current *Auth, renew func() (*Auth, error), revoke func() error) error {
+	stopCh, notifyCh := r.lazyInit()
+
+	timer := time.NewTimer(time.Until(renewAfterTime(current)))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if err := revoke(); err != nil {
+				r.notify(notifyCh, err)
+			}
+			return ctx.Err()
+		case <-stopCh:
+			if err := revoke(); err != nil {
+				r.notify(notifyCh, err)
+			}
+			return nil
+		case <-timer.C:
+			auth, err := renew()
+			if err != nil {
+				r.notify(notifyCh, err)
+				timer.Reset(renewalJitter)
+				continue
+			}
+			current = auth
+			timer.Reset(time.Until(renewAfterTime(current)))
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package vaultclient
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/mitchellh/mapstructure"
+)
+
+// HasSecret is implemented by anything that carries the raw Vault secret it
+// was decoded from, so Destroy can revoke its lease regardless of the
+// higher-level shape (UsernamePassword, ApiKey, ...) returned to the caller.
+type HasSecret interface {
+	vaultSecret() (*api.Client, *api.Secret)
+}
+
+// Secret is an opaque handle to the *api.Secret backing a decoded helper
+// result. Embed it in higher-level result types to make them a HasSecret.
+type Secret struct {
+	client *api.Client
+	raw    *api.Secret
+}
+
+func (s *Secret) vaultSecret() (*api.Client, *api.Secret) { return s.client, s.raw }
+
+// Destroy revokes the lease backing s, if it has one.
+func Destroy(s HasSecret) error {
+	client, raw := s.vaultSecret()
+	if raw == nil || raw.LeaseID == "" {
+		return nil
+	}
+	return client.Sys().Revoke(raw.LeaseID)
+}
+
+// UsernamePassword is the common shape for KV and database secrets engine
+// credentials.
+type UsernamePassword struct {
+	Secret
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+}
+
+// ApiKey is a KV secret holding a single api_key field.
+type ApiKey struct {
+	Secret
+	ApiKey string `mapstructure:"api_key"`
+}
+
+// kvData unwraps a KV v2 envelope (data.data/data.metadata) down to the
+// secret's actual fields; KV v1 secrets are returned as-is.
+func kvData(secret *api.Secret) map[string]interface{} {
+	data := secret.Data
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		if _, ok := data["metadata"].(map[string]interface{}); ok {
+			return nested
+		}
+	}
+	return data
+}
+
+// KV reads the KV v1 or v2 secret at path and decodes its fields into out.
+func KV(ctx context.Context, v VaultAuth, path string, out interface{}) error {
+	client, err := v.VaultClient()
+	if err != nil {
+		return err
+	}
+
+	secret, err := client.Logical().ReadWithContext(ctx, path)
+	if err != nil {
+		return err
+	}
+	if secret == nil {
+		return fmt.Errorf("vaultclient: no secret found at %q", path)
+	}
+
+	return mapstructure.Decode(kvData(secret), out)
+}
+
+// KVUserPass reads the KV secret at path and decodes it as a
+// UsernamePassword.
+func KVUserPass(ctx context.Context, v VaultAuth, path string) (*UsernamePassword, error) {
+	client, err := v.VaultClient()
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := client.Logical().ReadWithContext(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil {
+		return nil, fmt.Errorf("vaultclient: no secret found at %q", path)
+	}
+
+	up := &UsernamePassword{Secret: Secret{client: client, raw: secret}}
+	if err := mapstructure.Decode(kvData(secret), up); err != nil {
+		return nil, err
+	}
+	return up, nil
+}
+
+// KVApiKey reads the KV secret at path and decodes it as an ApiKey.
+func KVApiKey(ctx context.Context, v VaultAuth, path string) (*ApiKey, error) {
+	client, err := v.VaultClient()
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := client.Logical().ReadWithContext(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil {
+		return nil, fmt.Errorf("vaultclient: no secret found at %q", path)
+	}
+
+	key := &ApiKey{Secret: Secret{client: client, raw: secret}}
+	if err := mapstructure.Decode(kvData(secret), key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// DBCredential requests a dynamic credential from the database secrets
+// engine for role and decodes it as a UsernamePassword.
+func DBCredential(ctx context.Context, v VaultAuth, role string) (*UsernamePassword, error) {
+	client, err := v.VaultClient()
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := client.Logical().ReadWithContext(ctx, fmt.Sprintf("database/creds/%s", role))
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil {
+		return nil, fmt.Errorf("vaultclient: no database credential found for role %q", role)
+	}
+
+	up := &UsernamePassword{Secret: Secret{client: client, raw: secret}}
+	if err := mapstructure.Decode(secret.Data, up); err != nil {
+		return nil, err
+	}
+	return up, nil
+}